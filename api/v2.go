@@ -97,6 +97,7 @@ func AddV2Routes(ctx *Context, router *web.Router, path string, indexBytes []byt
 		Get("/transactions/:id", (*V2Context).GetTransaction).
 		Get("/addresses", (*V2Context).ListAddresses).
 		Get("/addresses/:id", (*V2Context).GetAddress).
+		Get("/addresses/:id/balances", (*V2Context).GetAddressBalancesAt).
 		Get("/outputs", (*V2Context).ListOutputs).
 		Get("/outputs/:id", (*V2Context).GetOutput).
 		Get("/assets", (*V2Context).ListAssets).
@@ -345,6 +346,40 @@ func (c *V2Context) GetAddress(w web.ResponseWriter, r *web.Request) {
 	})
 }
 
+func (c *V2Context) GetAddressBalancesAt(w web.ResponseWriter, r *web.Request) {
+	collectors := metrics.NewCollectors(
+		metrics.NewCounterObserveMillisCollect(MetricMillis),
+		metrics.NewCounterIncCollect(MetricCount),
+		metrics.NewCounterObserveMillisCollect(MetricAddressesMillis),
+		metrics.NewCounterIncCollect(MetricAddressesCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.AddressBalancesAtParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	id, err := params.AddressFromString(r.PathParams["id"])
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	p.Address = &id
+	p.ChainIDs = params.ForValueChainID(c.chainID, p.ChainIDs)
+
+	c.WriteCacheable(w, Cacheable{
+		TTL: 5 * time.Second,
+		Key: c.cacheKeyForParams("get_address_balances_at", p),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.avaxReader.AddressBalancesAt(ctx, p)
+		},
+	})
+}
+
 func (c *V2Context) AddressChains(w web.ResponseWriter, r *web.Request) {
 	collectors := metrics.NewCollectors(
 		metrics.NewCounterObserveMillisCollect(MetricMillis),
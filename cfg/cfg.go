@@ -55,6 +55,14 @@ type DB struct {
 	RODSN  string `json:"rodsn"`
 	Driver string `json:"driver"`
 	TXDB   bool   `json:"txDB"`
+
+	// Connection pool limits. Zero keeps the default (unlimited open, 32 idle,
+	// 5 minute idle time and lifetime); a negative value means no idle
+	// connections for MaxIdleConns and no limit for the others.
+	MaxOpenConns    int           `json:"maxOpenConns"`
+	MaxIdleConns    int           `json:"maxIdleConns"`
+	ConnMaxIdleTime time.Duration `json:"connMaxIdleTime"`
+	ConnMaxLifetime time.Duration `json:"connMaxLifetime"`
 }
 
 type Redis struct {
@@ -142,6 +150,11 @@ func NewFromFile(filePath string) (*Config, error) {
 				DSN:    dbdsn,
 				RODSN:  dbrodsn,
 				TXDB:   servicesDBViper.GetBool(keysServicesDBTXDB),
+
+				MaxOpenConns:    servicesDBViper.GetInt(keysServicesDBMaxOpenConns),
+				MaxIdleConns:    servicesDBViper.GetInt(keysServicesDBMaxIdleConns),
+				ConnMaxIdleTime: servicesDBViper.GetDuration(keysServicesDBConnMaxIdleTime),
+				ConnMaxLifetime: servicesDBViper.GetDuration(keysServicesDBConnMaxLifetime),
 			},
 			Redis: &Redis{
 				Addr:     servicesRedisViper.GetString(keysServicesRedisAddr),
@@ -24,6 +24,11 @@ const (
 	keysServicesDBRODSN  = "ro_dsn"
 	keysServicesDBTXDB   = "txDB"
 
+	keysServicesDBMaxOpenConns    = "maxOpenConns"
+	keysServicesDBMaxIdleConns    = "maxIdleConns"
+	keysServicesDBConnMaxIdleTime = "connMaxIdleTime"
+	keysServicesDBConnMaxLifetime = "connMaxLifetime"
+
 	keysServicesRedis         = "redis"
 	keysServicesRedisAddr     = "addr"
 	keysServicesRedisPassword = "password"
@@ -22,14 +22,20 @@ import (
 
 const (
 	MaxAggregateIntervalCount = 20000
+	MaxAggregateAssetCount    = 1000
 
 	MinSearchQueryLength = 1
 )
 
 var (
+	// aggregateAssetsChunkSize bounds the IN list of each AggregateAssets query
+	aggregateAssetsChunkSize = 100
+
 	ErrAggregateIntervalCountTooLarge = errors.New("requesting too many intervals")
+	ErrAggregateAssetCountTooLarge    = errors.New("requesting too many assets")
 	ErrFailedToParseStringAsBigInt    = errors.New("failed to parse string to big.Int")
 	ErrSearchQueryTooShort            = errors.New("search query too short")
+	ErrAddressRequired                = errors.New("address is required")
 
 	outputSelectColumns = []string{
 		"avm_outputs.id",
@@ -46,6 +52,26 @@ var (
 		"avm_outputs.payload",
 		"avm_outputs.frozen",
 	}
+
+	// assetInfoColumns are the per-asset balance totals loaded into
+	// models.AssetInfo, grouped by avm_outputs.asset_id
+	assetInfoColumns = []string{
+		"avm_outputs.asset_id",
+		"COUNT(DISTINCT(avm_outputs.transaction_id)) AS transaction_count",
+		"COALESCE(SUM(avm_outputs.amount), 0) AS total_received",
+		"COALESCE(SUM(CASE WHEN avm_outputs_redeeming.redeeming_transaction_id IS NOT NULL THEN avm_outputs.amount ELSE 0 END), 0) AS total_sent",
+		"COALESCE(SUM(CASE WHEN avm_outputs_redeeming.redeeming_transaction_id IS NULL THEN avm_outputs.amount ELSE 0 END), 0) AS balance",
+		"COALESCE(SUM(CASE WHEN avm_outputs_redeeming.redeeming_transaction_id IS NULL THEN 1 ELSE 0 END), 0) AS utxo_count",
+	}
+
+	// changeOutputCondition matches an avm_outputs row paid back to an
+	// address that owns one of the inputs its transaction spends
+	changeOutputCondition = "EXISTS (" +
+		"SELECT 1 FROM avm_outputs_redeeming AS change_inputs " +
+		"JOIN avm_output_addresses AS change_input_addresses ON change_input_addresses.output_id = change_inputs.id " +
+		"JOIN avm_output_addresses AS change_output_addresses ON change_output_addresses.address = change_input_addresses.address " +
+		"WHERE change_inputs.redeeming_transaction_id = avm_outputs.transaction_id " +
+		"AND change_output_addresses.output_id = avm_outputs.id)"
 )
 
 type Reader struct {
@@ -295,40 +321,144 @@ func (r *Reader) TxfeeAggregate(ctx context.Context, params *params.TxfeeAggrega
 }
 
 func (r *Reader) Aggregate(ctx context.Context, params *params.AggregateParams) (*models.AggregatesHistogram, error) {
+	intervalSeconds, requestedIntervalCount, err := r.aggregateIntervals(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the query and load the base data
+	dbRunner, err := r.conns.DB().NewSession("get_transaction_aggregates_histogram", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var intervals []models.Aggregates
+	builder := aggregateQuery(dbRunner, params, intervalSeconds, requestedIntervalCount)
+
+	if params.AssetID != nil {
+		builder.Where("avm_outputs.asset_id = ?", params.AssetID.String())
+	}
+
+	if requestedIntervalCount > 0 {
+		builder.
+			GroupBy("idx").
+			OrderAsc("idx").
+			Limit(uint64(requestedIntervalCount))
+	}
+
+	_, err = builder.LoadContext(ctx, &intervals)
+	if err != nil {
+		return nil, err
+	}
+
+	return aggregateHistogram(params, intervals, intervalSeconds, requestedIntervalCount)
+}
+
+// AggregateAssets returns the aggregates of each of the given assets, keyed by
+// asset id. It is equivalent to calling Aggregate once per asset with
+// params.AssetID set, but loads the assets in chunks of at most
+// aggregateAssetsChunkSize with one query per chunk.
+func (r *Reader) AggregateAssets(ctx context.Context, params *params.AggregateParams, assetIDs []ids.ID) (map[models.StringID]*models.AggregatesHistogram, error) {
+	if len(assetIDs) > MaxAggregateAssetCount {
+		return nil, ErrAggregateAssetCountTooLarge
+	}
+
+	intervalSeconds, requestedIntervalCount, err := r.aggregateIntervals(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	dbRunner, err := r.conns.DB().NewSession("get_asset_aggregates_histogram", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	intervalsByAsset := make(map[models.StringID][]models.Aggregates, len(assetIDs))
+	for start := 0; start < len(assetIDs); start += aggregateAssetsChunkSize {
+		end := start + aggregateAssetsChunkSize
+		if end > len(assetIDs) {
+			end = len(assetIDs)
+		}
+
+		chunk := make([]string, 0, end-start)
+		for _, assetID := range assetIDs[start:end] {
+			chunk = append(chunk, assetID.String())
+		}
+
+		var rows []*struct {
+			AssetID models.StringID `json:"assetID"`
+			models.Aggregates
+		}
+
+		builder := aggregateQuery(dbRunner, params, intervalSeconds, requestedIntervalCount, "avm_outputs.asset_id").
+			Where("avm_outputs.asset_id IN ?", chunk)
+
+		if requestedIntervalCount > 0 {
+			builder.
+				GroupBy("avm_outputs.asset_id", "idx").
+				OrderAsc("avm_outputs.asset_id").
+				OrderAsc("idx")
+		} else {
+			builder.GroupBy("avm_outputs.asset_id")
+		}
+
+		if _, err = builder.LoadContext(ctx, &rows); err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			intervalsByAsset[row.AssetID] = append(intervalsByAsset[row.AssetID], row.Aggregates)
+		}
+	}
+
+	aggs := make(map[models.StringID]*models.AggregatesHistogram, len(assetIDs))
+	for _, assetID := range assetIDs {
+		id := models.StringID(assetID.String())
+		if aggs[id], err = aggregateHistogram(params, intervalsByAsset[id], intervalSeconds, requestedIntervalCount); err != nil {
+			return nil, err
+		}
+	}
+	return aggs, nil
+}
+
+// aggregateIntervals fills in the default start time and returns the interval
+// length in seconds and the number of intervals requested, which is zero when
+// a single total is requested
+func (r *Reader) aggregateIntervals(ctx context.Context, params *params.AggregateParams) (int64, int, error) {
 	// Validate params and set defaults if necessary
 	if params.ListParams.StartTime.IsZero() {
 		var err error
 		params.ListParams.StartTime, err = r.getFirstTransactionTime(ctx, params.ChainIDs)
 		if err != nil {
-			return nil, err
+			return 0, 0, err
 		}
 	}
 
-	var intervals []models.Aggregates
-
 	// Ensure the interval count requested isn't too large
 	intervalSeconds := int64(params.IntervalSize.Seconds())
 	requestedIntervalCount := 0
 	if intervalSeconds != 0 {
 		requestedIntervalCount = int(math.Ceil(params.ListParams.EndTime.Sub(params.ListParams.StartTime).Seconds() / params.IntervalSize.Seconds()))
 		if requestedIntervalCount > MaxAggregateIntervalCount {
-			return nil, ErrAggregateIntervalCountTooLarge
+			return 0, 0, ErrAggregateIntervalCountTooLarge
 		}
 		if requestedIntervalCount < 1 {
 			requestedIntervalCount = 1
 		}
 	}
+	return intervalSeconds, requestedIntervalCount, nil
+}
 
-	// Build the query and load the base data
-	dbRunner, err := r.conns.DB().NewSession("get_transaction_aggregates_histogram", cfg.RequestTimeout)
-	if err != nil {
-		return nil, err
+// aggregateQuery builds the aggregate select over the requested time range and
+// chains, plus any extra columns, without any asset filter or grouping
+func aggregateQuery(dbRunner dbr.SessionRunner, params *params.AggregateParams, intervalSeconds int64, requestedIntervalCount int, extraColumns ...string) *dbr.SelectStmt {
+	volumeColumn := "COALESCE(SUM(avm_outputs.amount), 0) AS transaction_volume"
+	if params.ExcludeChange {
+		volumeColumn = "COALESCE(SUM(CASE WHEN " + changeOutputCondition + " THEN 0 ELSE avm_outputs.amount END), 0) AS transaction_volume"
 	}
 
-	var builder *dbr.SelectStmt
-
 	columns := []string{
-		"COALESCE(SUM(avm_outputs.amount), 0) AS transaction_volume",
+		volumeColumn,
 
 		"COUNT(DISTINCT(avm_outputs.transaction_id)) AS transaction_count",
 		"COUNT(DISTINCT(avm_output_addresses.address)) AS address_count",
@@ -342,8 +472,9 @@ func (r *Reader) Aggregate(ctx context.Context, params *params.AggregateParams)
 			params.ListParams.StartTime.Unix(),
 			intervalSeconds))
 	}
+	columns = append(columns, extraColumns...)
 
-	builder = dbRunner.
+	builder := dbRunner.
 		Select(columns...).
 		From("avm_outputs").
 		LeftJoin("avm_output_addresses", "avm_output_addresses.output_id = avm_outputs.id").
@@ -354,22 +485,12 @@ func (r *Reader) Aggregate(ctx context.Context, params *params.AggregateParams)
 		builder.Where("avm_outputs.chain_id IN ?", params.ChainIDs)
 	}
 
-	if params.AssetID != nil {
-		builder.Where("avm_outputs.asset_id = ?", params.AssetID.String())
-	}
-
-	if requestedIntervalCount > 0 {
-		builder.
-			GroupBy("idx").
-			OrderAsc("idx").
-			Limit(uint64(requestedIntervalCount))
-	}
-
-	_, err = builder.LoadContext(ctx, &intervals)
-	if err != nil {
-		return nil, err
-	}
+	return builder
+}
 
+// aggregateHistogram builds the response from the rows loaded by
+// aggregateQuery, ordered by idx when intervals were requested
+func aggregateHistogram(params *params.AggregateParams, intervals []models.Aggregates, intervalSeconds int64, requestedIntervalCount int) (*models.AggregatesHistogram, error) {
 	// If no intervals were requested then the total aggregate is equal to the
 	// first (and only) interval, and we're done
 	if requestedIntervalCount == 0 {
@@ -378,6 +499,16 @@ func (r *Reader) Aggregate(ctx context.Context, params *params.AggregateParams)
 		if len(intervals) > 0 {
 			intervals[0].StartTime = params.ListParams.StartTime
 			intervals[0].EndTime = params.ListParams.EndTime
+			if params.Cumulative {
+				cumulativeVolume, ok := new(big.Int).SetString(string(intervals[0].TransactionVolume), 10)
+				if !ok {
+					return nil, ErrFailedToParseStringAsBigInt
+				}
+				if params.CumulativeBaseline != nil {
+					cumulativeVolume.Add(cumulativeVolume, params.CumulativeBaseline)
+				}
+				intervals[0].CumulativeVolume = models.TokenAmount(cumulativeVolume.String())
+			}
 			return &models.AggregatesHistogram{
 				Aggregates: intervals[0],
 				StartTime:  params.ListParams.StartTime,
@@ -404,10 +535,22 @@ func (r *Reader) Aggregate(ctx context.Context, params *params.AggregateParams)
 			time.Unix(startTS+intervalSeconds-1, 0).UTC()
 	}
 
+	// The running total for cumulative reads, nil when not requested
+	var cumulativeVolume *big.Int
+	if params.Cumulative {
+		cumulativeVolume = big.NewInt(0)
+		if params.CumulativeBaseline != nil {
+			cumulativeVolume.Set(params.CumulativeBaseline)
+		}
+	}
+
 	padTo := func(slice []models.Aggregates, to int) []models.Aggregates {
 		for i := len(slice); i < to; i = len(slice) {
 			slice = append(slice, models.Aggregates{Idx: i})
 			slice[i].StartTime, slice[i].EndTime = timesForInterval(i)
+			if cumulativeVolume != nil {
+				slice[i].CumulativeVolume = models.TokenAmount(cumulativeVolume.String())
+			}
 		}
 		return slice
 	}
@@ -438,6 +581,10 @@ func (r *Reader) Aggregate(ctx context.Context, params *params.AggregateParams)
 
 		// Add to the overall aggregates counts
 		totalVolume.Add(totalVolume, intervalVolume)
+		if cumulativeVolume != nil {
+			cumulativeVolume.Add(cumulativeVolume, intervalVolume)
+			interval.CumulativeVolume = models.TokenAmount(cumulativeVolume.String())
+		}
 		aggs.Aggregates.TransactionCount += interval.TransactionCount
 		aggs.Aggregates.OutputCount += interval.OutputCount
 		aggs.Aggregates.AddressCount += interval.AddressCount
@@ -448,6 +595,9 @@ func (r *Reader) Aggregate(ctx context.Context, params *params.AggregateParams)
 	}
 	// Add total aggregated token amounts
 	aggs.Aggregates.TransactionVolume = models.TokenAmount(totalVolume.String())
+	if cumulativeVolume != nil {
+		aggs.Aggregates.CumulativeVolume = models.TokenAmount(cumulativeVolume.String())
+	}
 
 	// Add any missing trailing intervals
 	aggs.Intervals = padTo(aggs.Intervals, requestedIntervalCount)
@@ -732,6 +882,48 @@ func (r *Reader) GetAddress(ctx context.Context, p *params.ListAddressesParams)
 	return nil, err
 }
 
+// AddressBalancesAt reconstructs the per-asset balances of an address as they
+// were at p.AsOf from the raw outputs. Outputs created after p.AsOf are ignored
+// and outputs redeemed after p.AsOf are still counted as unspent.
+func (r *Reader) AddressBalancesAt(ctx context.Context, p *params.AddressBalancesAtParams) (*models.AddressInfo, error) {
+	if p.Address == nil {
+		return nil, ErrAddressRequired
+	}
+	address := *p.Address
+
+	dbRunner, err := r.conns.DB().NewSession("get_address_balances_at", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*models.AssetInfo
+	builder := dbRunner.
+		Select(assetInfoColumns...).
+		From("avm_outputs").
+		Join("avm_output_addresses", "avm_output_addresses.output_id = avm_outputs.id").
+		LeftJoin("avm_outputs_redeeming", dbr.Expr("avm_outputs.id = avm_outputs_redeeming.id AND avm_outputs_redeeming.redeemed_at <= ?", p.AsOf)).
+		Where("avm_output_addresses.address = ?", models.ToAddress(address)).
+		Where("avm_outputs.created_at <= ?", p.AsOf).
+		GroupBy("avm_outputs.asset_id")
+
+	if len(p.ChainIDs) > 0 {
+		builder.Where("avm_outputs.chain_id IN ?", p.ChainIDs)
+	}
+
+	if _, err = builder.LoadContext(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	addr := &models.AddressInfo{
+		Address: models.ToAddress(address),
+		Assets:  make(map[models.StringID]models.AssetInfo, len(rows)),
+	}
+	for _, row := range rows {
+		addr.Assets[row.AssetID] = *row
+	}
+	return addr, nil
+}
+
 func (r *Reader) GetOutput(ctx context.Context, id ids.ID) (*models.Output, error) {
 	outputList, err := r.ListOutputs(ctx,
 		&params.ListOutputsParams{
@@ -1193,15 +1385,7 @@ func (r *Reader) dressAddresses(ctx context.Context, dbRunner dbr.SessionRunner,
 	}
 
 	builder := dbRunner.
-		Select(
-			"avm_output_addresses.address",
-			"avm_outputs.asset_id",
-			"COUNT(DISTINCT(avm_outputs.transaction_id)) AS transaction_count",
-			"COALESCE(SUM(avm_outputs.amount), 0) AS total_received",
-			"COALESCE(SUM(CASE WHEN avm_outputs_redeeming.redeeming_transaction_id IS NOT NULL THEN avm_outputs.amount ELSE 0 END), 0) AS total_sent",
-			"COALESCE(SUM(CASE WHEN avm_outputs_redeeming.redeeming_transaction_id IS NULL THEN avm_outputs.amount ELSE 0 END), 0) AS balance",
-			"COALESCE(SUM(CASE WHEN avm_outputs_redeeming.redeeming_transaction_id IS NULL THEN 1 ELSE 0 END), 0) AS utxo_count",
-		).
+		Select(append([]string{"avm_output_addresses.address"}, assetInfoColumns...)...).
 		From("avm_outputs").
 		LeftJoin("avm_output_addresses", "avm_output_addresses.output_id = avm_outputs.id").
 		LeftJoin("avm_outputs_redeeming", "avm_outputs.id = avm_outputs_redeeming.id").
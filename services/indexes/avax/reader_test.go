@@ -5,6 +5,8 @@ package avax
 
 import (
 	"context"
+	"math"
+	"math/big"
 	"testing"
 	"time"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/ava-labs/ortelius/services/indexes/models"
 
 	"github.com/alicebob/miniredis"
+	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/utils/logging"
 	"github.com/ava-labs/ortelius/cfg"
 	"github.com/ava-labs/ortelius/services/indexes/params"
@@ -176,6 +179,426 @@ func TestAggregateTxfee(t *testing.T) {
 	}
 }
 
+func TestAddressBalancesAt(t *testing.T) {
+	reader, closeFn := newTestIndex(t)
+	defer closeFn()
+
+	ctx := newTestContext()
+
+	persist := services.NewPersist()
+
+	sess, _ := reader.conns.DB().NewSession("test_address_balances_at", cfg.RequestTimeout)
+	_, _ = sess.DeleteFrom("avm_outputs").ExecContext(ctx)
+	_, _ = sess.DeleteFrom("avm_output_addresses").ExecContext(ctx)
+	_, _ = sess.DeleteFrom("avm_outputs_redeeming").ExecContext(ctx)
+
+	address := ids.ShortID{1}
+	assetID := "assid1"
+	tnow := time.Now().UTC().Truncate(1 * time.Second).Add(-1 * time.Hour)
+
+	// out1 and out2 are received, out1 is spent later, and out3 arrives last
+	outputs := []struct {
+		id        string
+		amount    uint64
+		createdAt time.Time
+	}{
+		{"out1", 10, tnow},
+		{"out2", 5, tnow.Add(10 * time.Minute)},
+		{"out3", 7, tnow.Add(30 * time.Minute)},
+	}
+	for _, output := range outputs {
+		_ = persist.InsertOutputs(ctx, sess, &services.Outputs{
+			ID:            output.id,
+			ChainID:       "ch1",
+			TransactionID: "tx_" + output.id,
+			AssetID:       assetID,
+			OutputType:    models.OutputTypesSECP2556K1Transfer,
+			Amount:        output.amount,
+			CreatedAt:     output.createdAt,
+		}, false)
+		_ = persist.InsertOutputAddresses(ctx, sess, &services.OutputAddresses{
+			OutputID:  output.id,
+			Address:   address.String(),
+			CreatedAt: output.createdAt,
+		}, false)
+	}
+	_ = persist.InsertOutputsRedeeming(ctx, sess, &services.OutputsRedeeming{
+		ID:                     "out1",
+		RedeemedAt:             tnow.Add(20 * time.Minute),
+		RedeemingTransactionID: "tx_spend",
+		Amount:                 10,
+		AssetID:                assetID,
+		CreatedAt:              tnow.Add(20 * time.Minute),
+	}, false)
+
+	tests := []struct {
+		asOf          time.Time
+		balance       models.TokenAmount
+		totalReceived models.TokenAmount
+		totalSent     models.TokenAmount
+		utxoCount     uint64
+	}{
+		{tnow.Add(15 * time.Minute), "15", "15", "0", 2},
+		{tnow.Add(25 * time.Minute), "5", "15", "10", 1},
+		{tnow.Add(35 * time.Minute), "12", "22", "10", 2},
+	}
+	for _, test := range tests {
+		addr, err := reader.AddressBalancesAt(ctx, &params.AddressBalancesAtParams{Address: &address, AsOf: test.asOf})
+		if err != nil {
+			t.Fatal("error", err)
+		}
+		asset, ok := addr.Assets[models.StringID(assetID)]
+		if !ok {
+			t.Fatal("asset missing at", test.asOf)
+		}
+		if asset.Balance != test.balance ||
+			asset.TotalReceived != test.totalReceived ||
+			asset.TotalSent != test.totalSent ||
+			asset.UTXOCount != test.utxoCount {
+			t.Error("invalid balance at", test.asOf, asset)
+		}
+	}
+
+	addr, err := reader.AddressBalancesAt(ctx, &params.AddressBalancesAtParams{Address: &address, AsOf: tnow.Add(-1 * time.Minute)})
+	if err != nil {
+		t.Fatal("error", err)
+	}
+	if len(addr.Assets) != 0 {
+		t.Error("expected no assets before the first output", addr.Assets)
+	}
+}
+
+func TestAggregateTransactionVolumeOverflow(t *testing.T) {
+	reader, closeFn := newTestIndex(t)
+	defer closeFn()
+
+	ctx := newTestContext()
+
+	persist := services.NewPersist()
+
+	sess, _ := reader.conns.DB().NewSession("test_aggregate_transaction_volume_overflow", cfg.RequestTimeout)
+	_, _ = sess.DeleteFrom("avm_outputs").ExecContext(ctx)
+	_, _ = sess.DeleteFrom("avm_output_addresses").ExecContext(ctx)
+
+	tnow := time.Now().UTC().Truncate(1 * time.Hour).Add(-3 * time.Hour)
+
+	// out1 and out2 fall in the first hour and out3 in the second, so both the
+	// first interval and the total sum well past 2^63 and 2^64
+	outputs := []struct {
+		id        string
+		createdAt time.Time
+	}{
+		{"out1", tnow.Add(10 * time.Minute)},
+		{"out2", tnow.Add(20 * time.Minute)},
+		{"out3", tnow.Add(70 * time.Minute)},
+	}
+	for _, output := range outputs {
+		_ = persist.InsertOutputs(ctx, sess, &services.Outputs{
+			ID:            output.id,
+			ChainID:       "ch1",
+			TransactionID: "tx_" + output.id,
+			AssetID:       "assid1",
+			OutputType:    models.OutputTypesSECP2556K1Transfer,
+			Amount:        math.MaxUint64,
+			CreatedAt:     output.createdAt,
+		}, false)
+	}
+
+	listParams := params.ListParams{StartTime: tnow, EndTime: tnow.Add(2 * time.Hour)}
+
+	agg, err := reader.Aggregate(ctx, &params.AggregateParams{ListParams: listParams})
+	if err != nil {
+		t.Fatal("error", err)
+	}
+	if agg.Aggregates.TransactionVolume != models.TokenAmount("55340232221128654845") {
+		t.Error("aggregate volume invalid", agg.Aggregates.TransactionVolume)
+	}
+
+	agg, err = reader.Aggregate(ctx, &params.AggregateParams{ListParams: listParams, IntervalSize: time.Hour})
+	if err != nil {
+		t.Fatal("error", err)
+	}
+	if agg.Aggregates.TransactionVolume != models.TokenAmount("55340232221128654845") {
+		t.Error("aggregate volume invalid", agg.Aggregates.TransactionVolume)
+	}
+	if len(agg.Intervals) != 2 {
+		t.Fatal("invalid interval count", len(agg.Intervals))
+	}
+	if agg.Intervals[0].TransactionVolume != models.TokenAmount("36893488147419103230") ||
+		agg.Intervals[1].TransactionVolume != models.TokenAmount("18446744073709551615") {
+		t.Error("interval volume invalid", agg.Intervals)
+	}
+}
+
+func TestAggregateCumulativeVolume(t *testing.T) {
+	reader, closeFn := newTestIndex(t)
+	defer closeFn()
+
+	ctx := newTestContext()
+
+	persist := services.NewPersist()
+
+	sess, _ := reader.conns.DB().NewSession("test_aggregate_cumulative_volume", cfg.RequestTimeout)
+	_, _ = sess.DeleteFrom("avm_outputs").ExecContext(ctx)
+	_, _ = sess.DeleteFrom("avm_output_addresses").ExecContext(ctx)
+
+	tnow := time.Now().UTC().Truncate(1 * time.Hour).Add(-5 * time.Hour)
+
+	// the second hour has no outputs, and the last one pushes the running
+	// total past 2^64
+	outputs := []struct {
+		id        string
+		amount    uint64
+		createdAt time.Time
+	}{
+		{"out1", 10, tnow.Add(10 * time.Minute)},
+		{"out2", 5, tnow.Add(20 * time.Minute)},
+		{"out3", 7, tnow.Add(130 * time.Minute)},
+		{"out4", math.MaxUint64, tnow.Add(190 * time.Minute)},
+	}
+	for _, output := range outputs {
+		_ = persist.InsertOutputs(ctx, sess, &services.Outputs{
+			ID:            output.id,
+			ChainID:       "ch1",
+			TransactionID: "tx_" + output.id,
+			AssetID:       "assid1",
+			OutputType:    models.OutputTypesSECP2556K1Transfer,
+			Amount:        output.amount,
+			CreatedAt:     output.createdAt,
+		}, false)
+	}
+
+	p := &params.AggregateParams{
+		ListParams:         params.ListParams{StartTime: tnow, EndTime: tnow.Add(4 * time.Hour)},
+		IntervalSize:       time.Hour,
+		Cumulative:         true,
+		CumulativeBaseline: big.NewInt(100),
+	}
+	agg, err := reader.Aggregate(ctx, p)
+	if err != nil {
+		t.Fatal("error", err)
+	}
+	if len(agg.Intervals) != 4 {
+		t.Fatal("invalid interval count", len(agg.Intervals))
+	}
+
+	expected := []models.TokenAmount{"115", "115", "122", "18446744073709551737"}
+	prefixSum := big.NewInt(100)
+	for i, interval := range agg.Intervals {
+		if interval.TransactionVolume != "" {
+			volume, ok := new(big.Int).SetString(string(interval.TransactionVolume), 10)
+			if !ok {
+				t.Fatal("invalid volume", interval.TransactionVolume)
+			}
+			prefixSum.Add(prefixSum, volume)
+		}
+		if interval.CumulativeVolume != models.TokenAmount(prefixSum.String()) ||
+			interval.CumulativeVolume != expected[i] {
+			t.Error("cumulative volume invalid", i, interval.CumulativeVolume)
+		}
+	}
+	if agg.Aggregates.CumulativeVolume != "18446744073709551737" {
+		t.Error("cumulative volume invalid", agg.Aggregates.CumulativeVolume)
+	}
+
+	p.IntervalSize = 0
+	agg, err = reader.Aggregate(ctx, p)
+	if err != nil {
+		t.Fatal("error", err)
+	}
+	if agg.Aggregates.CumulativeVolume != "18446744073709551737" {
+		t.Error("cumulative volume invalid", agg.Aggregates.CumulativeVolume)
+	}
+
+	p.Cumulative = false
+	agg, err = reader.Aggregate(ctx, p)
+	if err != nil {
+		t.Fatal("error", err)
+	}
+	if agg.Aggregates.CumulativeVolume != "" {
+		t.Error("cumulative volume set when not requested", agg.Aggregates.CumulativeVolume)
+	}
+}
+
+func TestAggregateExcludeChange(t *testing.T) {
+	reader, closeFn := newTestIndex(t)
+	defer closeFn()
+
+	ctx := newTestContext()
+
+	persist := services.NewPersist()
+
+	sess, _ := reader.conns.DB().NewSession("test_aggregate_exclude_change", cfg.RequestTimeout)
+	_, _ = sess.DeleteFrom("avm_outputs").ExecContext(ctx)
+	_, _ = sess.DeleteFrom("avm_output_addresses").ExecContext(ctx)
+	_, _ = sess.DeleteFrom("avm_outputs_redeeming").ExecContext(ctx)
+
+	tnow := time.Now().UTC().Truncate(1 * time.Second).Add(-1 * time.Hour)
+	sender := ids.ShortID{1}.String()
+	receiver := ids.ShortID{2}.String()
+
+	// tx1 spends in1, owned by the sender, paying 60 to the receiver and
+	// 39 back to the sender as change
+	_ = persist.InsertOutputAddresses(ctx, sess, &services.OutputAddresses{
+		OutputID:  "in1",
+		Address:   sender,
+		CreatedAt: tnow.Add(-2 * time.Hour),
+	}, false)
+	_ = persist.InsertOutputsRedeeming(ctx, sess, &services.OutputsRedeeming{
+		ID:                     "in1",
+		RedeemedAt:             tnow,
+		RedeemingTransactionID: "tx1",
+		Amount:                 100,
+		AssetID:                "assid1",
+		CreatedAt:              tnow,
+	}, false)
+
+	outputs := []struct {
+		id      string
+		address string
+		amount  uint64
+	}{
+		{"out1", receiver, 60},
+		{"out2", sender, 39},
+	}
+	for _, output := range outputs {
+		_ = persist.InsertOutputs(ctx, sess, &services.Outputs{
+			ID:            output.id,
+			ChainID:       "ch1",
+			TransactionID: "tx1",
+			AssetID:       "assid1",
+			OutputType:    models.OutputTypesSECP2556K1Transfer,
+			Amount:        output.amount,
+			CreatedAt:     tnow,
+		}, false)
+		_ = persist.InsertOutputAddresses(ctx, sess, &services.OutputAddresses{
+			OutputID:  output.id,
+			Address:   output.address,
+			CreatedAt: tnow,
+		}, false)
+	}
+
+	p := &params.AggregateParams{ListParams: params.ListParams{StartTime: tnow.Add(-1 * time.Minute), EndTime: tnow.Add(1 * time.Minute)}}
+	agg, err := reader.Aggregate(ctx, p)
+	if err != nil {
+		t.Fatal("error", err)
+	}
+	if agg.Aggregates.TransactionVolume != "99" {
+		t.Error("aggregate volume invalid", agg.Aggregates.TransactionVolume)
+	}
+
+	p.ExcludeChange = true
+	agg, err = reader.Aggregate(ctx, p)
+	if err != nil {
+		t.Fatal("error", err)
+	}
+	if agg.Aggregates.TransactionVolume != "60" {
+		t.Error("aggregate volume with change excluded invalid", agg.Aggregates.TransactionVolume)
+	}
+	if agg.Aggregates.OutputCount != 2 {
+		t.Error("change output not counted", agg.Aggregates.OutputCount)
+	}
+
+	addr, err := reader.AddressBalancesAt(ctx, &params.AddressBalancesAtParams{Address: &ids.ShortID{1}, AsOf: tnow})
+	if err != nil {
+		t.Fatal("error", err)
+	}
+	if addr.Assets["assid1"].Balance != "39" {
+		t.Error("change output not counted for balance", addr.Assets["assid1"].Balance)
+	}
+}
+
+func TestAggregateAssets(t *testing.T) {
+	reader, closeFn := newTestIndex(t)
+	defer closeFn()
+
+	ctx := newTestContext()
+
+	persist := services.NewPersist()
+
+	sess, _ := reader.conns.DB().NewSession("test_aggregate_assets", cfg.RequestTimeout)
+	_, _ = sess.DeleteFrom("avm_outputs").ExecContext(ctx)
+	_, _ = sess.DeleteFrom("avm_output_addresses").ExecContext(ctx)
+
+	tnow := time.Now().UTC().Truncate(1 * time.Hour).Add(-3 * time.Hour)
+	assetIDs := []ids.ID{{1}, {2}, {3}, {4}}
+
+	// asset 4 has no outputs
+	outputs := []struct {
+		id        string
+		assetID   ids.ID
+		amount    uint64
+		createdAt time.Time
+	}{
+		{"out1", assetIDs[0], 10, tnow.Add(10 * time.Minute)},
+		{"out2", assetIDs[0], 5, tnow.Add(70 * time.Minute)},
+		{"out3", assetIDs[1], 7, tnow.Add(20 * time.Minute)},
+		{"out4", assetIDs[2], math.MaxUint64, tnow.Add(80 * time.Minute)},
+		{"out5", assetIDs[2], math.MaxUint64, tnow.Add(90 * time.Minute)},
+	}
+	for _, output := range outputs {
+		_ = persist.InsertOutputs(ctx, sess, &services.Outputs{
+			ID:            output.id,
+			ChainID:       "ch1",
+			TransactionID: "tx_" + output.id,
+			AssetID:       output.assetID.String(),
+			OutputType:    models.OutputTypesSECP2556K1Transfer,
+			Amount:        output.amount,
+			CreatedAt:     output.createdAt,
+		}, false)
+	}
+
+	defer func(chunkSize int) { aggregateAssetsChunkSize = chunkSize }(aggregateAssetsChunkSize)
+	aggregateAssetsChunkSize = 3
+
+	listParams := params.ListParams{StartTime: tnow, EndTime: tnow.Add(2 * time.Hour)}
+	for _, intervalSize := range []time.Duration{0, time.Hour} {
+		aggs, err := reader.AggregateAssets(ctx, &params.AggregateParams{ListParams: listParams, IntervalSize: intervalSize}, assetIDs)
+		if err != nil {
+			t.Fatal("error", err)
+		}
+		if len(aggs) != len(assetIDs) {
+			t.Fatal("invalid asset count", len(aggs))
+		}
+
+		for _, assetID := range assetIDs {
+			assetID := assetID
+			expected, err := reader.Aggregate(ctx, &params.AggregateParams{ListParams: listParams, IntervalSize: intervalSize, AssetID: &assetID})
+			if err != nil {
+				t.Fatal("error", err)
+			}
+			agg, ok := aggs[models.StringID(assetID.String())]
+			if !ok {
+				t.Fatal("asset missing", assetID)
+			}
+			if agg.Aggregates.OutputCount != expected.Aggregates.OutputCount ||
+				len(agg.Intervals) != len(expected.Intervals) {
+				t.Error("asset aggregates differ from Aggregate", assetID, agg.Aggregates, expected.Aggregates)
+			}
+			for i := range agg.Intervals {
+				if agg.Intervals[i].TransactionVolume != expected.Intervals[i].TransactionVolume {
+					t.Error("asset interval differs from Aggregate", assetID, i, agg.Intervals[i].TransactionVolume)
+				}
+			}
+		}
+
+		volumes := map[ids.ID]models.TokenAmount{
+			assetIDs[0]: "15",
+			assetIDs[1]: "7",
+			assetIDs[2]: "36893488147419103230",
+		}
+		for assetID, volume := range volumes {
+			if v := aggs[models.StringID(assetID.String())].Aggregates.TransactionVolume; v != volume {
+				t.Error("invalid asset volume", assetID, v)
+			}
+		}
+	}
+
+	if _, err := reader.AggregateAssets(ctx, &params.AggregateParams{ListParams: listParams}, make([]ids.ID, MaxAggregateAssetCount+1)); err != ErrAggregateAssetCountTooLarge {
+		t.Error("expected too many assets", err)
+	}
+}
+
 func newTestIndex(t *testing.T) (*Reader, func()) {
 	// Start test redis
 	s, err := miniredis.Run()
@@ -126,6 +126,10 @@ type Aggregates struct {
 	AddressCount      uint64      `json:"addressCount"`
 	OutputCount       uint64      `json:"outputCount"`
 	AssetCount        uint64      `json:"assetCount"`
+
+	// CumulativeVolume is the running total of TransactionVolume up to and
+	// including this interval, set only when a cumulative read is requested.
+	CumulativeVolume TokenAmount `json:"cumulativeVolume,omitempty"`
 }
 
 type AddressChains struct {
@@ -5,7 +5,10 @@ package models
 
 import (
 	"encoding/json"
+	"errors"
+	"math/big"
 	"strconv"
+	"strings"
 
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/utils/constants"
@@ -87,6 +90,9 @@ func (addr Address) MarshalString() ([]byte, error) {
 	return []byte(bech32Addr), nil
 }
 
+// ErrInvalidTokenAmount is returned when a TokenAmount is not a base-10 integer.
+var ErrInvalidTokenAmount = errors.New("invalid token amount")
+
 // AssetTokenCounts maps asset IDs to a TokenAmount for that asset.
 type AssetTokenCounts map[StringID]TokenAmount
 
@@ -97,3 +103,43 @@ type TokenAmount string
 func TokenAmountForUint64(i uint64) TokenAmount {
 	return TokenAmount(strconv.Itoa(int(i)))
 }
+
+// Cmp compares t and other as integers, so "10" is greater than "9", and
+// returns -1, 0 or +1 like big.Int.Cmp.
+func (t TokenAmount) Cmp(other TokenAmount) (int, error) {
+	x, ok := new(big.Int).SetString(string(t), 10)
+	if !ok {
+		return 0, ErrInvalidTokenAmount
+	}
+	y, ok := new(big.Int).SetString(string(other), 10)
+	if !ok {
+		return 0, ErrInvalidTokenAmount
+	}
+	return x.Cmp(y), nil
+}
+
+// FormatVolume converts an amount in base units into a decimal string for an
+// asset with the given denomination, e.g. "1500000000" with a denomination of
+// 9 is "1.5". Trailing fractional zeros are dropped. Values that are not
+// base-10 integers are returned unchanged.
+func FormatVolume(raw string, denomination uint8) string {
+	amount, ok := new(big.Int).SetString(raw, 10)
+	if !ok {
+		return raw
+	}
+
+	digits := new(big.Int).Abs(amount).String()
+	decimals := int(denomination)
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+
+	volume := digits[:len(digits)-decimals]
+	if fraction := strings.TrimRight(digits[len(digits)-decimals:], "0"); fraction != "" {
+		volume += "." + fraction
+	}
+	if amount.Sign() < 0 {
+		volume = "-" + volume
+	}
+	return volume
+}
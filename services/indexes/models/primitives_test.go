@@ -0,0 +1,59 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestFormatVolume(t *testing.T) {
+	tests := []struct {
+		raw          string
+		denomination uint8
+		expected     string
+	}{
+		{"0", 0, "0"},
+		{"12345", 0, "12345"},
+		{"0", 9, "0"},
+		{"1500000000", 9, "1.5"},
+		{"1000000000", 9, "1"},
+		{"1", 9, "0.000000001"},
+		{"123456789", 9, "0.123456789"},
+		{"-2500", 3, "-2.5"},
+		{"340282366920938463463374607431768211456", 18, "340282366920938463463.374607431768211456"},
+		{"not a number", 9, "not a number"},
+	}
+	for _, test := range tests {
+		if v := FormatVolume(test.raw, test.denomination); v != test.expected {
+			t.Errorf("FormatVolume(%s, %d) = %s, expected %s", test.raw, test.denomination, v, test.expected)
+		}
+	}
+}
+
+func TestTokenAmountCmp(t *testing.T) {
+	tests := []struct {
+		a, b     TokenAmount
+		expected int
+	}{
+		{"10", "9", 1},
+		{"9", "10", -1},
+		{"10", "10", 0},
+		{"0", "0", 0},
+		{"36893488147419103230", "18446744073709551615", 1},
+		{"-1", "0", -1},
+	}
+	for _, test := range tests {
+		c, err := test.a.Cmp(test.b)
+		if err != nil {
+			t.Fatal("error", err)
+		}
+		if c != test.expected {
+			t.Errorf("%s.Cmp(%s) = %d, expected %d", test.a, test.b, c, test.expected)
+		}
+	}
+
+	if _, err := TokenAmount("1.5").Cmp("1"); err != ErrInvalidTokenAmount {
+		t.Error("expected invalid token amount", err)
+	}
+	if _, err := TokenAmount("1").Cmp(""); err != ErrInvalidTokenAmount {
+		t.Error("expected invalid token amount", err)
+	}
+}
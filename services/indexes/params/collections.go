@@ -4,6 +4,7 @@
 package params
 
 import (
+	"math/big"
 	"net/url"
 	"strconv"
 	"strings"
@@ -27,6 +28,7 @@ var (
 	_ Param = &ListTransactionsParams{}
 	_ Param = &ListAssetsParams{}
 	_ Param = &ListAddressesParams{}
+	_ Param = &AddressBalancesAtParams{}
 	_ Param = &ListOutputsParams{}
 )
 
@@ -88,6 +90,15 @@ type AggregateParams struct {
 	AssetID      *ids.ID
 	IntervalSize time.Duration
 	Version      int
+
+	// Cumulative adds a running total of the volume to each interval,
+	// starting from CumulativeBaseline when it is set
+	Cumulative         bool
+	CumulativeBaseline *big.Int
+
+	// ExcludeChange leaves change outputs, those paid to an address that also
+	// owns one of the transaction's inputs, out of the transaction volume
+	ExcludeChange bool
 }
 
 func (p *AggregateParams) ForValues(version uint8, q url.Values) (err error) {
@@ -113,6 +124,23 @@ func (p *AggregateParams) ForValues(version uint8, q url.Values) (err error) {
 		return err
 	}
 
+	p.Cumulative, err = GetQueryBool(q, KeyCumulative, false)
+	if err != nil {
+		return err
+	}
+
+	if baseline := GetQueryString(q, KeyCumulativeBase, ""); baseline != "" {
+		var ok bool
+		if p.CumulativeBaseline, ok = new(big.Int).SetString(baseline, 10); !ok {
+			return ErrInvalidCumulativeBaseline
+		}
+	}
+
+	p.ExcludeChange, err = GetQueryBool(q, KeyExcludeChange, false)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -123,6 +151,17 @@ func (p *AggregateParams) CacheKey() []string {
 		k = append(k, CacheKey(KeyAssetID, p.AssetID.String()))
 	}
 
+	if p.ExcludeChange {
+		k = append(k, CacheKey(KeyExcludeChange, p.ExcludeChange))
+	}
+
+	if p.Cumulative {
+		k = append(k, CacheKey(KeyCumulative, p.Cumulative))
+		if p.CumulativeBaseline != nil {
+			k = append(k, CacheKey(KeyCumulativeBase, p.CumulativeBaseline.String()))
+		}
+	}
+
 	k = append(k,
 		CacheKey(KeyIntervalSize, int64(p.IntervalSize.Seconds())),
 		CacheKey(KeyChainID, strings.Join(p.ChainIDs, "|")),
@@ -414,6 +453,48 @@ func (p *ListAddressesParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
 	return b
 }
 
+type AddressBalancesAtParams struct {
+	ChainIDs []string
+	Address  *ids.ShortID
+	AsOf     time.Time
+}
+
+func (p *AddressBalancesAtParams) ForValues(_ uint8, q url.Values) error {
+	p.ChainIDs = q[KeyChainID]
+
+	var err error
+	if p.Address, err = GetQueryAddress(q, KeyAddress); err != nil {
+		return err
+	}
+
+	provided, asOf, err := GetQueryTime(q, KeyAsOf)
+	if err != nil {
+		return err
+	}
+	if !provided {
+		return ErrAsOfRequired
+	}
+	if asOf.After(time.Now()) {
+		return ErrAsOfInFuture
+	}
+	p.AsOf = asOf.Round(TransactionRoundDuration)
+
+	return nil
+}
+
+func (p *AddressBalancesAtParams) CacheKey() []string {
+	var k []string
+
+	if p.Address != nil {
+		k = append(k, CacheKey(KeyAddress, p.Address.String()))
+	}
+
+	return append(k,
+		CacheKey(KeyChainID, strings.Join(p.ChainIDs, "|")),
+		CacheKey(KeyAsOf, p.AsOf.Unix()),
+	)
+}
+
 type AddressChainsParams struct {
 	ListParams ListParams
 	Addresses  []ids.ShortID
@@ -1,7 +1,10 @@
 package params
 
 import (
+	"net/url"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/ava-labs/avalanchego/utils/hashing"
 
@@ -35,3 +38,60 @@ func TestForValueChainID(t *testing.T) {
 		t.Error("ForValueChainID failed")
 	}
 }
+
+func TestAddressBalancesAtParams(t *testing.T) {
+	asOf := time.Now().UTC().Add(-1 * time.Hour).Truncate(time.Second)
+
+	p := &AddressBalancesAtParams{}
+	err := p.ForValues(2, url.Values{KeyAsOf: []string{asOf.Format(time.RFC3339)}})
+	if err != nil {
+		t.Fatal("ForValues failed", err)
+	}
+	if !p.AsOf.Equal(asOf) {
+		t.Error("asOf not parsed", p.AsOf)
+	}
+
+	p = &AddressBalancesAtParams{}
+	err = p.ForValues(2, url.Values{KeyAsOf: []string{strconv.FormatInt(asOf.Unix(), 10)}})
+	if err != nil {
+		t.Fatal("ForValues failed", err)
+	}
+	if !p.AsOf.Equal(asOf) {
+		t.Error("asOf not parsed", p.AsOf)
+	}
+
+	p = &AddressBalancesAtParams{}
+	if err = p.ForValues(2, url.Values{}); err != ErrAsOfRequired {
+		t.Error("expected asOf required", err)
+	}
+
+	future := time.Now().Add(1 * time.Hour).Format(time.RFC3339)
+	p = &AddressBalancesAtParams{}
+	if err = p.ForValues(2, url.Values{KeyAsOf: []string{future}}); err != ErrAsOfInFuture {
+		t.Error("expected asOf in future", err)
+	}
+
+	p = &AddressBalancesAtParams{}
+	if err = p.ForValues(2, url.Values{KeyAsOf: []string{"yesterday"}}); err == nil {
+		t.Error("expected invalid asOf")
+	}
+}
+
+func TestAggregateParamsCumulative(t *testing.T) {
+	p := &AggregateParams{}
+	err := p.ForValues(2, url.Values{
+		KeyCumulative:     []string{"true"},
+		KeyCumulativeBase: []string{"36893488147419103230"},
+	})
+	if err != nil {
+		t.Fatal("ForValues failed", err)
+	}
+	if !p.Cumulative || p.CumulativeBaseline == nil || p.CumulativeBaseline.String() != "36893488147419103230" {
+		t.Error("cumulative not parsed", p.Cumulative, p.CumulativeBaseline)
+	}
+
+	p = &AggregateParams{}
+	if err = p.ForValues(2, url.Values{KeyCumulativeBase: []string{"1.5"}}); err != ErrInvalidCumulativeBaseline {
+		t.Error("expected invalid cumulative baseline", err)
+	}
+}
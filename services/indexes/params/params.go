@@ -33,6 +33,10 @@ const (
 	KeyEnableAggregate  = "enableAggregate"
 	KeyOutputOutputType = "outputOutputType"
 	KeyOutputGroupID    = "outputGroupId"
+	KeyAsOf             = "asOf"
+	KeyCumulative       = "cumulative"
+	KeyCumulativeBase   = "cumulativeBaseline"
+	KeyExcludeChange    = "excludeChange"
 
 	PaginationMaxLimit      = 5000
 	PaginationDefaultOffset = 0
@@ -59,6 +63,10 @@ var (
 	}
 
 	ErrUndefinedSort = errors.New("undefined sort")
+	ErrAsOfRequired  = errors.New("asOf is required")
+	ErrAsOfInFuture  = errors.New("asOf is in the future")
+
+	ErrInvalidCumulativeBaseline = errors.New("invalid cumulative baseline")
 
 	// Ensure params types satisfy the interface
 	_ Param = &ListParams{}
@@ -3,6 +3,7 @@ package services
 import (
 	"time"
 
+	"github.com/ava-labs/ortelius/services/db"
 	"github.com/ava-labs/ortelius/services/metrics"
 
 	"github.com/ava-labs/avalanchego/utils/logging"
@@ -18,6 +19,10 @@ const (
 	MetricConsumeProcessMillisCounterKey = "consume_records_process_millis"
 	MetricConsumeSuccessCountKey         = "consume_records_success"
 	MetricConsumeFailureCountKey         = "consume_records_failure"
+
+	defaultMaxIdleConns    = 32
+	defaultConnMaxIdleTime = 5 * time.Minute
+	defaultConnMaxLifetime = 5 * time.Minute
 )
 
 type Control struct {
@@ -48,9 +53,7 @@ func (s *Control) Database() (*Connections, error) {
 	if err != nil {
 		return nil, err
 	}
-	c.DB().SetMaxIdleConns(32)
-	c.DB().SetConnMaxIdleTime(5 * time.Minute)
-	c.DB().SetConnMaxLifetime(5 * time.Minute)
+	s.configurePool(c.DB())
 	return c, err
 }
 
@@ -59,8 +62,50 @@ func (s *Control) DatabaseRO() (*Connections, error) {
 	if err != nil {
 		return nil, err
 	}
-	c.DB().SetMaxIdleConns(32)
-	c.DB().SetConnMaxIdleTime(5 * time.Minute)
-	c.DB().SetConnMaxLifetime(5 * time.Minute)
+	s.configurePool(c.DB())
 	return c, err
 }
+
+// poolLimits holds the connection pool settings applied to a db.Conn
+type poolLimits struct {
+	maxOpenConns    int
+	maxIdleConns    int
+	connMaxIdleTime time.Duration
+	connMaxLifetime time.Duration
+}
+
+// newPoolLimits resolves the pool settings for conf. Zero values fall back to
+// the defaults and negative values are passed through to database/sql, which
+// treats them as no idle connections or an unlimited idle time and lifetime.
+func newPoolLimits(conf *cfg.DB) poolLimits {
+	limits := poolLimits{
+		maxIdleConns:    defaultMaxIdleConns,
+		connMaxIdleTime: defaultConnMaxIdleTime,
+		connMaxLifetime: defaultConnMaxLifetime,
+	}
+	if conf == nil {
+		return limits
+	}
+	if conf.MaxOpenConns != 0 {
+		limits.maxOpenConns = conf.MaxOpenConns
+	}
+	if conf.MaxIdleConns != 0 {
+		limits.maxIdleConns = conf.MaxIdleConns
+	}
+	if conf.ConnMaxIdleTime != 0 {
+		limits.connMaxIdleTime = conf.ConnMaxIdleTime
+	}
+	if conf.ConnMaxLifetime != 0 {
+		limits.connMaxLifetime = conf.ConnMaxLifetime
+	}
+	return limits
+}
+
+// configurePool applies the configured pool limits to conn
+func (s *Control) configurePool(conn *db.Conn) {
+	limits := newPoolLimits(s.Services.DB)
+	conn.SetMaxOpenConns(limits.maxOpenConns)
+	conn.SetMaxIdleConns(limits.maxIdleConns)
+	conn.SetConnMaxIdleTime(limits.connMaxIdleTime)
+	conn.SetConnMaxLifetime(limits.connMaxLifetime)
+}
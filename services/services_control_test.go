@@ -0,0 +1,37 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ava-labs/ortelius/cfg"
+)
+
+func TestNewPoolLimits(t *testing.T) {
+	defaults := poolLimits{
+		maxIdleConns:    defaultMaxIdleConns,
+		connMaxIdleTime: defaultConnMaxIdleTime,
+		connMaxLifetime: defaultConnMaxLifetime,
+	}
+
+	tests := []struct {
+		conf     *cfg.DB
+		expected poolLimits
+	}{
+		{nil, defaults},
+		{&cfg.DB{}, defaults},
+		{
+			&cfg.DB{MaxOpenConns: 7, MaxIdleConns: 3, ConnMaxIdleTime: time.Minute, ConnMaxLifetime: time.Hour},
+			poolLimits{maxOpenConns: 7, maxIdleConns: 3, connMaxIdleTime: time.Minute, connMaxLifetime: time.Hour},
+		},
+		{
+			&cfg.DB{MaxIdleConns: -1, ConnMaxIdleTime: -1, ConnMaxLifetime: -1},
+			poolLimits{maxIdleConns: -1, connMaxIdleTime: -1, connMaxLifetime: -1},
+		},
+	}
+	for _, test := range tests {
+		if limits := newPoolLimits(test.conf); limits != test.expected {
+			t.Errorf("newPoolLimits(%v) = %v, expected %v", test.conf, limits, test.expected)
+		}
+	}
+}